@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/jinzhu/gorm/logger"
@@ -14,12 +16,12 @@ import (
 func initializeCallbacks(db *DB) *callbacks {
 	return &callbacks{
 		processors: map[string]*processor{
-			"create": &processor{db: db},
-			"query":  &processor{db: db},
-			"update": &processor{db: db},
-			"delete": &processor{db: db},
-			"row":    &processor{db: db},
-			"raw":    &processor{db: db},
+			"create": &processor{db: db, name: "create"},
+			"query":  &processor{db: db, name: "query"},
+			"update": &processor{db: db, name: "update"},
+			"delete": &processor{db: db, name: "delete"},
+			"row":    &processor{db: db, name: "row"},
+			"raw":    &processor{db: db, name: "raw"},
 		},
 	}
 }
@@ -30,8 +32,16 @@ type callbacks struct {
 }
 
 type processor struct {
-	db        *DB
-	fns       []func(*DB)
+	db *DB
+	// create/query/update/delete/row/raw, used as the %o directive in formatted SQL logs
+	name string
+
+	mu         sync.Mutex
+	dirty      bool
+	plan       []string
+	fns        []func(*DB)
+	compileErr error
+
 	callbacks []*callback
 }
 
@@ -39,9 +49,11 @@ type callback struct {
 	name      string
 	before    string
 	after     string
+	priority  int
+	tags      []string
 	remove    bool
 	replace   bool
-	match     func(*DB) bool
+	conds     []func(*DB) bool
 	handler   func(*DB)
 	processor *processor
 }
@@ -71,6 +83,11 @@ func (cs *callbacks) Raw() *processor {
 }
 
 func (p *processor) Execute(db *DB) {
+	fns, err := p.compiled()
+	if err != nil {
+		db.AddError(err)
+	}
+
 	curTime := time.Now()
 	if stmt := db.Statement; stmt != nil {
 		if stmt.Model == nil {
@@ -85,15 +102,15 @@ func (p *processor) Execute(db *DB) {
 		stmt.ReflectValue = reflect.Indirect(reflect.ValueOf(stmt.Dest))
 	}
 
-	for _, f := range p.fns {
+	for _, f := range fns {
 		f(db)
 	}
 
 	if stmt := db.Statement; stmt != nil {
-		db.Error = stmt.Error
+		db.AddError(stmt.Error)
 		db.RowsAffected = stmt.RowsAffected
 
-		db.Logger.Trace(curTime, func() (string, int64) {
+		db.Logger.Trace(curTime, p.name, func() (string, int64) {
 			return db.Dialector.Explain(stmt.SQL.String(), stmt.Vars...), db.RowsAffected
 		}, db.Error)
 	}
@@ -108,6 +125,19 @@ func (p *processor) Get(name string) func(*DB) {
 	return nil
 }
 
+// Plan returns the compiled callback names for this processor, in the order
+// they will run. It recompiles first if the callback set has changed since
+// the last compile; if the current registrations fail to compile, it returns
+// the last successfully compiled plan.
+func (p *processor) Plan() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dirty {
+		p.compileLocked()
+	}
+	return p.plan
+}
+
 func (p *processor) Before(name string) *callback {
 	return &callback{before: name, processor: p}
 }
@@ -117,7 +147,26 @@ func (p *processor) After(name string) *callback {
 }
 
 func (p *processor) Match(fc func(*DB) bool) *callback {
-	return &callback{match: fc, processor: p}
+	return &callback{conds: []func(*DB) bool{fc}, processor: p}
+}
+
+// If registers a callback that only runs when cond returns true. It can be
+// chained off Match (or another If) to AND several conditions together.
+func (p *processor) If(cond func(*DB) bool) *callback {
+	return &callback{conds: []func(*DB) bool{cond}, processor: p}
+}
+
+// Priority sets the callback's priority among callbacks that have no
+// before/after constraint; lower runs earlier, ties broken by registration
+// order.
+func (p *processor) Priority(priority int) *callback {
+	return &callback{priority: priority, processor: p}
+}
+
+// Tag attaches one or more tags to a callback, so it can later be removed or
+// replaced as a group with RemoveByTag/ReplaceByTag.
+func (p *processor) Tag(tags ...string) *callback {
+	return &callback{tags: tags, processor: p}
 }
 
 func (p *processor) Register(name string, fn func(*DB)) error {
@@ -132,18 +181,83 @@ func (p *processor) Replace(name string, fn func(*DB)) error {
 	return (&callback{processor: p}).Replace(name, fn)
 }
 
-func (p *processor) compile() (err error) {
-	var callbacks []*callback
-	for _, callback := range p.callbacks {
-		if callback.match == nil || callback.match(p.db) {
-			callbacks = append(callbacks, callback)
+// RemoveByTag removes every registered callback carrying tag.
+func (p *processor) RemoveByTag(tag string) error {
+	for _, name := range p.taggedNames(tag) {
+		if err := (&callback{processor: p}).Remove(name); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// ReplaceByTag replaces the handler of every registered callback carrying tag.
+func (p *processor) ReplaceByTag(tag string, fn func(*DB)) error {
+	for _, name := range p.taggedNames(tag) {
+		if err := (&callback{processor: p}).Replace(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taggedNames returns the names of currently registered, non-removed
+// callbacks carrying tag, snapshotted under lock so it's safe to call
+// alongside concurrent Register/Remove/Replace calls.
+func (p *processor) taggedNames(tag string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var names []string
+	for _, c := range p.callbacks {
+		if !c.remove && hasTag(c.tags, tag) {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// addCallback appends c under lock and recompiles synchronously, returning a
+// real error on conflict so Register/Remove/Replace keep failing fast at
+// registration time instead of only surfacing the error on the next Execute.
+func (p *processor) addCallback(c *callback) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.callbacks = append(p.callbacks, c)
+	p.dirty = true
+	p.compileLocked()
+	return p.compileErr
+}
+
+// compiled returns the currently compiled plan, recompiling first if the
+// callback set has changed since the last compile. Since Register/Remove/
+// Replace already compile eagerly, this is normally just a cheap, lock-
+// guarded read of the last good plan together with the last compile error
+// (nil on success), which is cached rather than retried on every call.
+func (p *processor) compiled() ([]func(*DB), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dirty {
+		p.compileLocked()
+	}
+	return p.fns, p.compileErr
+}
 
-	if p.fns, err = sortCallbacks(p.callbacks); err != nil {
+// compileLocked must be called with p.mu held. It resolves p.dirty either
+// way: on success it stores the new plan; on failure it caches the error on
+// p.compileErr so callers don't pay the sort cost again until the callback
+// set actually changes.
+func (p *processor) compileLocked() {
+	plan, fns, err := sortCallbacks(p.callbacks)
+	p.dirty = false
+	p.compileErr = err
+	if err != nil {
 		logger.Default.Error("Got error when compile callbacks, got %v", err)
+		return
 	}
-	return
+
+	p.plan = plan
+	p.fns = fns
 }
 
 func (c *callback) Before(name string) *callback {
@@ -156,19 +270,41 @@ func (c *callback) After(name string) *callback {
 	return c
 }
 
+// Match adds a predicate that must return true for this callback to run;
+// it ANDs with any predicate already set via Match or If.
+func (c *callback) Match(fc func(*DB) bool) *callback {
+	c.conds = append(c.conds, fc)
+	return c
+}
+
+// If adds a predicate that must return true for this callback to run; it
+// ANDs with any predicate already set via Match or If.
+func (c *callback) If(cond func(*DB) bool) *callback {
+	c.conds = append(c.conds, cond)
+	return c
+}
+
+func (c *callback) Priority(priority int) *callback {
+	c.priority = priority
+	return c
+}
+
+func (c *callback) Tag(tags ...string) *callback {
+	c.tags = append(c.tags, tags...)
+	return c
+}
+
 func (c *callback) Register(name string, fn func(*DB)) error {
 	c.name = name
 	c.handler = fn
-	c.processor.callbacks = append(c.processor.callbacks, c)
-	return c.processor.compile()
+	return c.processor.addCallback(c)
 }
 
 func (c *callback) Remove(name string) error {
 	logger.Default.Warn("removing callback `%v` from %v\n", name, utils.FileWithLineNum())
 	c.name = name
 	c.remove = true
-	c.processor.callbacks = append(c.processor.callbacks, c)
-	return c.processor.compile()
+	return c.processor.addCallback(c)
 }
 
 func (c *callback) Replace(name string, fn func(*DB)) error {
@@ -176,8 +312,26 @@ func (c *callback) Replace(name string, fn func(*DB)) error {
 	c.name = name
 	c.handler = fn
 	c.replace = true
-	c.processor.callbacks = append(c.processor.callbacks, c)
-	return c.processor.compile()
+	return c.processor.addCallback(c)
+}
+
+// matches reports whether every condition attached via Match/If holds for db.
+func (c *callback) matches(db *DB) bool {
+	for _, cond := range c.conds {
+		if !cond(db) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
 }
 
 // getRIndex get right index from string slice
@@ -190,7 +344,12 @@ func getRIndex(strs []string, str string) int {
 	return -1
 }
 
-func sortCallbacks(cs []*callback) (fns []func(*DB), err error) {
+// sortCallbacks compiles cs into an execution plan. It runs in two passes:
+// first it topologically sorts by before/after constraints, exactly as
+// before; then, among the callbacks left unconstrained by before/after, it
+// stable-sorts by priority (ties keep their registration order) without
+// disturbing the position of any anchored callback.
+func sortCallbacks(cs []*callback) (plan []string, fns []func(*DB), err error) {
 	var (
 		names, sorted []string
 		sortCallback  func(*callback) error
@@ -260,9 +419,41 @@ func sortCallbacks(cs []*callback) (fns []func(*DB), err error) {
 		}
 	}
 
+	// second pass: stable-sort the unconstrained callbacks by priority,
+	// in place, without moving any before/after-anchored callback
+	var positions []int
+	var unconstrained []*callback
+	for i, name := range sorted {
+		c := cs[getRIndex(names, name)]
+		if c.before == "" && c.after == "" {
+			positions = append(positions, i)
+			unconstrained = append(unconstrained, c)
+		}
+	}
+
+	sort.SliceStable(unconstrained, func(i, j int) bool {
+		return unconstrained[i].priority < unconstrained[j].priority
+	})
+
+	for i, c := range unconstrained {
+		sorted[positions[i]] = c.name
+	}
+
 	for _, name := range sorted {
-		if idx := getRIndex(names, name); !cs[idx].remove {
-			fns = append(fns, cs[idx].handler)
+		idx := getRIndex(names, name)
+		if c := cs[idx]; !c.remove {
+			plan = append(plan, name)
+
+			handler := c.handler
+			if len(c.conds) > 0 {
+				fns = append(fns, func(db *DB) {
+					if c.matches(db) {
+						handler(db)
+					}
+				})
+			} else {
+				fns = append(fns, handler)
+			}
 		}
 	}
 
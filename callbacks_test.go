@@ -0,0 +1,213 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jinzhu/gorm/logger"
+)
+
+func newTestProcessor() *processor {
+	return &processor{db: &DB{}, name: "query"}
+}
+
+func TestSortCallbacksPriority(t *testing.T) {
+	p := newTestProcessor()
+	var order []string
+
+	record := func(name string) func(*DB) {
+		return func(*DB) { order = append(order, name) }
+	}
+
+	p.Priority(10).Register("low", record("low"))
+	p.Register("default1", record("default1"))
+	p.Priority(-5).Register("high", record("high"))
+	p.Register("default2", record("default2"))
+
+	p.Execute(&DB{})
+
+	if want := []string{"high", "default1", "default2", "low"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestSortCallbacksPriorityWithAnchors(t *testing.T) {
+	p := newTestProcessor()
+	var order []string
+	record := func(name string) func(*DB) {
+		return func(*DB) { order = append(order, name) }
+	}
+
+	p.Register("a", record("a"))
+	p.Priority(10).Register("c", record("c"))
+	p.Priority(-10).Register("b", record("b"))
+	p.Before("a").Register("anchor", record("anchor"))
+
+	p.Execute(&DB{})
+
+	// "anchor" keeps its slot before "a"; the unconstrained a/b/c reorder by priority in the remaining slots.
+	if want := []string{"anchor", "b", "a", "c"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestRemoveByTag(t *testing.T) {
+	p := newTestProcessor()
+	var order []string
+	record := func(name string) func(*DB) {
+		return func(*DB) { order = append(order, name) }
+	}
+
+	p.Tag("tenant").Register("a", record("a"))
+	p.Register("b", record("b"))
+	p.Tag("tenant").Register("c", record("c"))
+
+	if err := p.RemoveByTag("tenant"); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Execute(&DB{})
+	if want := []string{"b"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestReplaceByTag(t *testing.T) {
+	p := newTestProcessor()
+	var order []string
+	record := func(name string) func(*DB) {
+		return func(*DB) { order = append(order, name) }
+	}
+
+	p.Tag("tenant").Register("a", record("a"))
+	p.Register("b", record("b"))
+
+	if err := p.ReplaceByTag("tenant", record("replaced")); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Execute(&DB{})
+	if want := []string{"replaced", "b"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestConditionalMatchAndIf(t *testing.T) {
+	p := newTestProcessor()
+	var order []string
+	record := func(name string) func(*DB) {
+		return func(*DB) { order = append(order, name) }
+	}
+
+	enabled := true
+	p.Match(func(db *DB) bool { return enabled }).
+		If(func(db *DB) bool { return db.RowsAffected > 0 }).
+		Register("conditional", record("conditional"))
+	p.Register("always", record("always"))
+
+	p.Execute(&DB{RowsAffected: 0})
+	p.Execute(&DB{RowsAffected: 1})
+
+	if want := []string{"always", "conditional", "always"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+}
+
+func TestProcessorPlan(t *testing.T) {
+	p := newTestProcessor()
+	p.Register("a", func(*DB) {})
+	p.Priority(-1).Register("b", func(*DB) {})
+
+	if plan := p.Plan(); !reflect.DeepEqual(plan, []string{"b", "a"}) {
+		t.Fatalf("got %v, want %v", plan, []string{"b", "a"})
+	}
+}
+
+func TestRegisterConflictReturnsErrorImmediately(t *testing.T) {
+	p := newTestProcessor()
+
+	if err := p.Register("y", func(*DB) {}); err != nil {
+		t.Fatalf("unexpected error registering y: %v", err)
+	}
+
+	err := p.Before("y").After("y").Register("x", func(*DB) {})
+	if err == nil {
+		t.Fatal("expected a conflicting callback error, got nil")
+	}
+}
+
+func TestCompileFailureIsCachedNotRetried(t *testing.T) {
+	p := newTestProcessor()
+
+	if err := p.Register("y", func(*DB) {}); err != nil {
+		t.Fatalf("unexpected error registering y: %v", err)
+	}
+	if err := p.Before("y").After("y").Register("x", func(*DB) {}); err == nil {
+		t.Fatal("expected a conflicting callback error, got nil")
+	}
+
+	if p.dirty {
+		t.Fatal("a failed compile should still clear the dirty bit so it isn't retried on every Execute")
+	}
+
+	db1 := &DB{}
+	p.Execute(db1)
+	if db1.Error == nil {
+		t.Fatal("expected Execute to surface the cached compile error")
+	}
+
+	db2 := &DB{}
+	p.Execute(db2)
+	if db2.Error == nil {
+		t.Fatal("expected the second Execute to also surface the cached compile error")
+	}
+	if p.dirty {
+		t.Fatal("Execute should not have re-dirtied the processor")
+	}
+}
+
+func TestCompileFailureSurvivesStatementErrorOverwrite(t *testing.T) {
+	p := newTestProcessor()
+
+	if err := p.Register("y", func(*DB) {}); err != nil {
+		t.Fatalf("unexpected error registering y: %v", err)
+	}
+	if err := p.Before("y").After("y").Register("x", func(*DB) {}); err == nil {
+		t.Fatal("expected a conflicting callback error, got nil")
+	}
+
+	// Every real call path has a non-nil Statement with a nil Error; make
+	// sure Execute merges the compile error into db.Error instead of
+	// clobbering it with stmt.Error.
+	db := &DB{Logger: logger.Discard, Statement: &Statement{}}
+	p.Execute(db)
+	if db.Error == nil {
+		t.Fatal("expected Execute to surface the cached compile error even with a non-nil Statement")
+	}
+}
+
+func TestConcurrentRegisterAndExecute(t *testing.T) {
+	p := newTestProcessor()
+	if err := p.Register("base", func(*DB) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.Priority(i).Register(fmt.Sprintf("extra-%d", i), func(*DB) {})
+		}(i)
+	}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Execute(&DB{})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,339 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm/utils"
+)
+
+// Writer log writer interface
+type Writer interface {
+	Printf(string, ...interface{})
+}
+
+// Config logger config
+type Config struct {
+	SlowThreshold time.Duration
+	LogLevel      LogLevel
+
+	// Format is an Apache mod_log_config-style directive string controlling
+	// the layout of each SQL log line. It is only consulted by NewFormatted;
+	// a nil/empty Format there falls back to the default layout. See
+	// NewFormatted for the list of supported directives.
+	Format string
+}
+
+// LogLevel log level
+type LogLevel int
+
+const (
+	Silent LogLevel = iota + 1
+	Error
+	Warn
+	Info
+)
+
+// Interface logger interface
+type Interface interface {
+	LogMode(LogLevel) Interface
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+	Error(string, ...interface{})
+	Trace(begin time.Time, op string, fc func() (string, int64), err error)
+}
+
+var (
+	Discard = New(log.New(ioutil.Discard, "", log.LstdFlags), Config{})
+	Default = New(log.New(os.Stdout, "\r\n", log.LstdFlags), Config{
+		SlowThreshold: 100 * time.Millisecond,
+		LogLevel:      Warn,
+	})
+)
+
+// New initializes a logger with the hard-coded default layout.
+func New(writer Writer, config Config) Interface {
+	var (
+		infoStr      = "%s\n[info] "
+		warnStr      = "%s\n[warn] "
+		errStr       = "%s\n[error] "
+		traceStr     = "%s\n[%.3fms] [rows:%v] %s"
+		traceErrStr  = "%s %s\n[%.3fms] [rows:%v] %s"
+		traceWarnStr = "%s %s\n[%.3fms] [rows:%v] %s"
+	)
+
+	return &logger{
+		Writer:       writer,
+		Config:       config,
+		infoStr:      infoStr,
+		warnStr:      warnStr,
+		errStr:       errStr,
+		traceStr:     traceStr,
+		traceErrStr:  traceErrStr,
+		traceWarnStr: traceWarnStr,
+	}
+}
+
+type logger struct {
+	Writer
+	Config
+	infoStr, warnStr, errStr            string
+	traceStr, traceErrStr, traceWarnStr string
+}
+
+func (l *logger) LogMode(level LogLevel) Interface {
+	newlogger := *l
+	newlogger.LogLevel = level
+	return &newlogger
+}
+
+// Info print info
+func (l logger) Info(msg string, data ...interface{}) {
+	if l.LogLevel >= Info {
+		l.Printf(l.infoStr+msg, append([]interface{}{utils.FileWithLineNum()}, data...)...)
+	}
+}
+
+// Warn print warn messages
+func (l logger) Warn(msg string, data ...interface{}) {
+	if l.LogLevel >= Warn {
+		l.Printf(l.warnStr+msg, append([]interface{}{utils.FileWithLineNum()}, data...)...)
+	}
+}
+
+// Error print error messages
+func (l logger) Error(msg string, data ...interface{}) {
+	if l.LogLevel >= Error {
+		l.Printf(l.errStr+msg, append([]interface{}{utils.FileWithLineNum()}, data...)...)
+	}
+}
+
+// Trace print sql message
+func (l logger) Trace(begin time.Time, op string, fc func() (string, int64), err error) {
+	if l.LogLevel <= Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil:
+		sql, rows := fc()
+		if rows == -1 {
+			l.Printf(l.traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, "-", sql)
+		} else {
+			l.Printf(l.traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+		}
+	case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= Warn:
+		sql, rows := fc()
+		slowLog := fmt.Sprintf("SLOW SQL >= %v", l.SlowThreshold)
+		if rows == -1 {
+			l.Printf(l.traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, "-", sql)
+		} else {
+			l.Printf(l.traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+		}
+	case l.LogLevel >= Info:
+		sql, rows := fc()
+		if rows == -1 {
+			l.Printf(l.traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, "-", sql)
+		} else {
+			l.Printf(l.traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, rows, sql)
+		}
+	}
+}
+
+// traceRecord carries everything a compiled format segment might need to
+// render a single Trace call.
+type traceRecord struct {
+	begin   time.Time
+	elapsed time.Duration
+	op      string
+	sql     string
+	rows    int64
+	err     error
+	caller  string
+}
+
+// formatSegment renders one piece of a compiled Format string.
+type formatSegment func(w io.Writer, r *traceRecord)
+
+// formattedLogger renders Trace calls through a Format string compiled by
+// NewFormatted, instead of the logger struct's hard-coded layout.
+type formattedLogger struct {
+	*logger
+	out      io.Writer
+	segments []formatSegment
+}
+
+// NewFormatted builds a Logger whose Trace output is controlled by
+// cfg.Format, an Apache mod_log_config-style directive string. The format is
+// compiled once, at construction time, into a slice of segment renderers so
+// the per-query path does no parsing.
+//
+// Supported directives:
+//
+//	%t              start time, formatted as time.RFC3339Nano
+//	%{layout}t      start time, formatted with the given Go time layout
+//	%D              duration in microseconds
+//	%T              duration in seconds, as a float
+//	%s              the SQL statement, after Dialector.Explain
+//	%r              rows affected
+//	%e              the error string, or "-" when there is no error
+//	%f              caller file:line, from utils.FileWithLineNum
+//	%o{op}          operation name (create/query/update/delete/row/raw)
+//	%%              a literal percent sign
+//
+// Any other directive is left in the output verbatim. An empty cfg.Format
+// falls back to the default layout used by New, so existing callers of
+// logger.Default and logger.New are unaffected.
+func NewFormatted(w io.Writer, cfg Config) Interface {
+	if cfg.Format == "" {
+		return New(log.New(w, "", log.LstdFlags), cfg)
+	}
+
+	return &formattedLogger{
+		logger:   New(log.New(w, "", log.LstdFlags), cfg).(*logger),
+		out:      w,
+		segments: compileFormat(cfg.Format),
+	}
+}
+
+func (l *formattedLogger) LogMode(level LogLevel) Interface {
+	newlogger := *l
+	base := *l.logger
+	base.LogLevel = level
+	newlogger.logger = &base
+	return &newlogger
+}
+
+func (l *formattedLogger) Trace(begin time.Time, op string, fc func() (string, int64), err error) {
+	if l.LogLevel <= Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	slow := l.SlowThreshold != 0 && elapsed > l.SlowThreshold
+	switch {
+	case err != nil:
+	case slow && l.LogLevel >= Warn:
+	case l.LogLevel >= Info:
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	rec := &traceRecord{
+		begin:   begin,
+		elapsed: elapsed,
+		op:      op,
+		sql:     sql,
+		rows:    rows,
+		err:     err,
+		caller:  utils.FileWithLineNum(),
+	}
+
+	for _, segment := range l.segments {
+		segment(l.out, rec)
+	}
+}
+
+func compileFormat(format string) []formatSegment {
+	var (
+		segments []formatSegment
+		lit      []byte
+	)
+
+	flushLiteral := func() {
+		if len(lit) > 0 {
+			b := append([]byte(nil), lit...)
+			segments = append(segments, func(w io.Writer, _ *traceRecord) { w.Write(b) })
+			lit = lit[:0]
+		}
+	}
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i == len(format)-1 {
+			lit = append(lit, c)
+			continue
+		}
+
+		switch next := format[i+1]; next {
+		case '%':
+			lit = append(lit, '%')
+			i++
+		case '{':
+			// %{layout}t
+			end := strings.IndexByte(format[i+2:], '}')
+			if end == -1 || i+2+end+1 >= len(format) || format[i+2+end+1] != 't' {
+				lit = append(lit, '%')
+				continue
+			}
+			layout := format[i+2 : i+2+end]
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) {
+				io.WriteString(w, r.begin.Format(layout))
+			})
+			i += 2 + end + 1
+		case 't':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) {
+				io.WriteString(w, r.begin.Format(time.RFC3339Nano))
+			})
+			i++
+		case 'D':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) {
+				fmt.Fprintf(w, "%d", r.elapsed.Microseconds())
+			})
+			i++
+		case 'T':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) {
+				fmt.Fprintf(w, "%f", r.elapsed.Seconds())
+			})
+			i++
+		case 's':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) { io.WriteString(w, r.sql) })
+			i++
+		case 'r':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) { fmt.Fprintf(w, "%d", r.rows) })
+			i++
+		case 'e':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) {
+				if r.err == nil {
+					io.WriteString(w, "-")
+				} else {
+					io.WriteString(w, r.err.Error())
+				}
+			})
+			i++
+		case 'f':
+			flushLiteral()
+			segments = append(segments, func(w io.Writer, r *traceRecord) { io.WriteString(w, r.caller) })
+			i++
+		case 'o':
+			const tag = "{op}"
+			if i+2+len(tag) <= len(format) && format[i+2:i+2+len(tag)] == tag {
+				flushLiteral()
+				segments = append(segments, func(w io.Writer, r *traceRecord) { io.WriteString(w, r.op) })
+				i += 1 + len(tag)
+			} else {
+				lit = append(lit, '%')
+			}
+		default:
+			// unknown directive: leave verbatim
+			lit = append(lit, '%')
+		}
+	}
+
+	flushLiteral()
+	return segments
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFormatted(t *testing.T) {
+	ops := []string{"create", "query", "update", "delete", "row", "raw"}
+
+	for _, op := range ops {
+		t.Run(op+"/success", func(t *testing.T) {
+			var buf bytes.Buffer
+			l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%o{op} %s rows=%r err=%e"})
+
+			l.Trace(time.Now(), op, func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+			want := op + " SELECT 1 rows=1 err=-"
+			if got := buf.String(); got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		})
+
+		t.Run(op+"/error", func(t *testing.T) {
+			var buf bytes.Buffer
+			l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%o{op} %s rows=%r err=%e"})
+
+			l.Trace(time.Now(), op, func() (string, int64) { return "SELECT 1", -1 }, errors.New("boom"))
+
+			want := op + " SELECT 1 rows=-1 err=boom"
+			if got := buf.String(); got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		})
+
+		t.Run(op+"/slow", func(t *testing.T) {
+			var buf bytes.Buffer
+			l := NewFormatted(&buf, Config{LogLevel: Warn, SlowThreshold: time.Nanosecond, Format: "%o{op} %s"})
+
+			l.Trace(time.Now().Add(-time.Hour), op, func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+			want := op + " SELECT 1"
+			if got := buf.String(); got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestNewFormattedLiteralPercent(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "100%% done: %s"})
+	l.Trace(time.Now(), "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if want, got := "100% done: SELECT 1", buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewFormattedUnknownDirective(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%z%s"})
+	l.Trace(time.Now(), "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if want, got := "%zSELECT 1", buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewFormattedEmptyFormatFallsBackToDefault(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info})
+	l.Trace(time.Now(), "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if buf.Len() == 0 {
+		t.Fatal("expected default layout output, got nothing")
+	}
+}
+
+func TestNewFormattedTime(t *testing.T) {
+	begin := time.Date(2020, 1, 2, 3, 4, 5, 123000000, time.UTC)
+
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%t"})
+	l.Trace(begin, "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if want, got := begin.Format(time.RFC3339Nano), buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewFormattedCustomTimeLayout(t *testing.T) {
+	begin := time.Date(2020, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	const layout = "2006-01-02T15:04:05.000Z07:00"
+
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%{" + layout + "}t"})
+	l.Trace(begin, "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if want, got := begin.Format(layout), buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewFormattedDuration(t *testing.T) {
+	begin := time.Now().Add(-42 * time.Millisecond)
+
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%D"})
+	l.Trace(begin, "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	micros, err := strconv.ParseInt(buf.String(), 10, 64)
+	if err != nil {
+		t.Fatalf("expected an integer microsecond count, got %q: %v", buf.String(), err)
+	}
+	if micros < 42000 || micros > 5_000_000 {
+		t.Fatalf("expected roughly 42000 microseconds, got %d", micros)
+	}
+}
+
+func TestNewFormattedDurationSeconds(t *testing.T) {
+	begin := time.Now().Add(-42 * time.Millisecond)
+
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%T"})
+	l.Trace(begin, "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	seconds, err := strconv.ParseFloat(buf.String(), 64)
+	if err != nil {
+		t.Fatalf("expected a float second count, got %q: %v", buf.String(), err)
+	}
+	if seconds < 0.042 || seconds > 5 {
+		t.Fatalf("expected roughly 0.042 seconds, got %f", seconds)
+	}
+}
+
+func TestNewFormattedCallerFile(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Info, Format: "%f"})
+	l.Trace(time.Now(), "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if got := buf.String(); !strings.Contains(got, "logger_test.go") {
+		t.Fatalf("expected caller to point at this test file, got %q", got)
+	}
+}
+
+func TestNewFormattedSilentLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewFormatted(&buf, Config{LogLevel: Silent, Format: "%s"})
+	l.Trace(time.Now(), "query", func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output at Silent level, got %q", buf.String())
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var gormSourceDir string
+
+func init() {
+	_, file, _, _ := runtime.Caller(0)
+	gormSourceDir = filepath.Dir(filepath.Dir(file)) + string(os.PathSeparator)
+}
+
+// FileWithLineNum returns the file name and line number of the first caller
+// that is outside of the gorm source tree or is a _test.go file, so log output
+// points at user code or tests instead of gorm internals.
+func FileWithLineNum() string {
+	for i := 2; i < 15; i++ {
+		_, file, line, ok := runtime.Caller(i)
+		if ok && (!strings.HasPrefix(file, gormSourceDir) || strings.HasSuffix(file, "_test.go")) {
+			return file + ":" + strconv.Itoa(line)
+		}
+	}
+
+	return ""
+}